@@ -0,0 +1,39 @@
+package ente
+
+// PublicAccessContext is attached to the gin context (under
+// auth.PublicAccessKey) for requests authenticated by a public collection
+// link token. Scopes describes exactly what the link is allowed to do;
+// reconstructed from the persisted token on every request.
+type PublicAccessContext struct {
+	CollectionID int64
+	Scopes       ScopeSet
+}
+
+// NewPublicAccessContext builds a PublicAccessContext for collectionID.
+// scopes may be nil for tokens minted before scopes existed (or without an
+// explicit scope set); such tokens are promoted to DefaultLinkScope so
+// nothing that worked before this feature existed stops working.
+func NewPublicAccessContext(collectionID int64, scopes ScopeSet) PublicAccessContext {
+	if len(scopes) == 0 {
+		scopes = DefaultLinkScope()
+	}
+	return PublicAccessContext{CollectionID: collectionID, Scopes: scopes}
+}
+
+// FileLinkAccessContext is attached to the gin context (under
+// auth.FileLinkAccessKey) for requests authenticated by a public file link
+// token.
+type FileLinkAccessContext struct {
+	FileID int64
+	Scopes ScopeSet
+}
+
+// NewFileLinkAccessContext builds a FileLinkAccessContext for fileID,
+// promoting a nil/empty scope set to DefaultLinkScope (see
+// NewPublicAccessContext).
+func NewFileLinkAccessContext(fileID int64, scopes ScopeSet) *FileLinkAccessContext {
+	if len(scopes) == 0 {
+		scopes = DefaultLinkScope()
+	}
+	return &FileLinkAccessContext{FileID: fileID, Scopes: scopes}
+}
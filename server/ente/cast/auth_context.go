@@ -0,0 +1,23 @@
+// Package cast holds the types backing Ente's TV-cast pairing flow: a
+// short-lived code displayed on the TV is claimed by a phone, which
+// authorizes the TV session to receive a specific album.
+package cast
+
+import "github.com/ente-io/museum/ente"
+
+// AuthContext is attached to the gin context (under auth.CastContext) for
+// requests authenticated by a cast session token.
+type AuthContext struct {
+	CastSessionID string
+	Scopes        ente.ScopeSet
+}
+
+// NewAuthContext builds an AuthContext for a cast session, promoting a
+// nil/empty scope set to ente.DefaultLinkScope so cast sessions minted
+// before scoped tokens existed keep working unchanged.
+func NewAuthContext(castSessionID string, scopes ente.ScopeSet) AuthContext {
+	if len(scopes) == 0 {
+		scopes = ente.DefaultLinkScope()
+	}
+	return AuthContext{CastSessionID: castSessionID, Scopes: scopes}
+}
@@ -0,0 +1,67 @@
+package ente
+
+import "testing"
+
+func TestScopeSetAllowsUnconstrained(t *testing.T) {
+	set := NewScopeSet(ScopeFileThumbnail)
+
+	if !set.Allows(ScopeFileThumbnail, 0) {
+		t.Error("unconstrained scope should allow resourceID 0")
+	}
+	if !set.Allows(ScopeFileThumbnail, 42) {
+		t.Error("unconstrained scope should allow any resourceID")
+	}
+	if set.Allows(ScopeFileDownload, 42) {
+		t.Error("scope not in the set should never be allowed")
+	}
+}
+
+func TestScopeSetAllowsCollectionConstraint(t *testing.T) {
+	set := NewScopeSet(ScopeCollectionRead).
+		WithConstraint(ScopeCollectionRead, ResourceConstraint{CollectionID: 7})
+
+	if !set.Allows(ScopeCollectionRead, 7) {
+		t.Error("expected the constrained collection ID to be allowed")
+	}
+	if set.Allows(ScopeCollectionRead, 8) {
+		t.Error("a different collection ID must not be allowed")
+	}
+	if set.Allows(ScopeCollectionRead, 0) {
+		t.Error("resourceID 0 must not satisfy a real collection constraint")
+	}
+}
+
+func TestScopeSetAllowsFileIDConstraint(t *testing.T) {
+	set := NewScopeSet(ScopeFileDownload).
+		WithConstraint(ScopeFileDownload, ResourceConstraint{FileIDs: []int64{1, 2, 3}})
+
+	for _, id := range []int64{1, 2, 3} {
+		if !set.Allows(ScopeFileDownload, id) {
+			t.Errorf("expected file ID %d to be allowed", id)
+		}
+	}
+	if set.Allows(ScopeFileDownload, 4) {
+		t.Error("a file ID outside the constraint must not be allowed")
+	}
+}
+
+func TestWithConstraintDoesNotMutateOriginal(t *testing.T) {
+	base := NewScopeSet(ScopeCollectionRead)
+	restricted := base.WithConstraint(ScopeCollectionRead, ResourceConstraint{CollectionID: 7})
+
+	if !base.Allows(ScopeCollectionRead, 99) {
+		t.Error("the original scope set must remain unconstrained")
+	}
+	if restricted.Allows(ScopeCollectionRead, 99) {
+		t.Error("the copy's constraint must not leak back onto the original")
+	}
+}
+
+func TestDefaultLinkScopeAllowsEverything(t *testing.T) {
+	set := DefaultLinkScope()
+	for _, scope := range []Scope{ScopeCollectionRead, ScopeCollectionList, ScopeFileDownload, ScopeFileThumbnail, ScopeCastReceive} {
+		if !set.Allows(scope, 0) {
+			t.Errorf("legacy full link scope should allow %s", scope)
+		}
+	}
+}
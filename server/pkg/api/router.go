@@ -0,0 +1,103 @@
+// Package api wires the pieces built elsewhere in pkg/ (controllers,
+// middleware) onto actual gin routes.
+package api
+
+import (
+	"context"
+	"database/sql"
+
+	"time"
+
+	"github.com/ente-io/museum/ente"
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/museum/pkg/controller"
+	"github.com/ente-io/museum/pkg/middleware"
+	"github.com/ente-io/museum/pkg/repo"
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/ente-io/museum/pkg/utils/auth/oidc"
+	"github.com/ente-io/stacktrace"
+	"github.com/gin-gonic/gin"
+)
+
+// keyRotationCheckInterval is how often KeySet.StartRotationLoop wakes up
+// to check whether the active signing key is due for rotation. It should
+// be comfortably shorter than the keyset's rotation interval (default
+// 24h).
+const keyRotationCheckInterval = 5 * time.Minute
+
+// Dependencies bundles everything Setup needs to mount the routes this
+// series of changes adds. Other route groups (files, collections, ...)
+// are mounted by their own Setup functions elsewhere.
+type Dependencies struct {
+	DB            *sql.DB
+	Cache         cache.Cache
+	OIDCProviders []oidc.Config
+	KeySet        *auth.KeySet
+	Revocations   auth.RevocationStore
+}
+
+// Setup registers the OIDC login/callback routes, session logout, the
+// owner-authenticated link/cast mutation endpoints (which invalidate the
+// cached access context they affect), the JWKS endpoint, and the
+// public-link/file-link/cast-session read paths that the scoped access
+// contexts and RequireScope actually run against, onto router.
+func Setup(ctx context.Context, router gin.IRouter, deps Dependencies, sessionIssuer controller.SessionIssuer) error {
+	users := &repo.OIDCUserRepository{DB: deps.DB}
+	links := &repo.LinkRepository{DB: deps.DB}
+	sessions := &repo.SessionRepository{DB: deps.DB}
+	replayStore := oidc.NewCacheReplayStore(deps.Cache)
+
+	oidcController, err := controller.NewOIDCController(ctx, deps.OIDCProviders, replayStore, users, sessionIssuer)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to set up oidc controller")
+	}
+
+	sessionController := &controller.SessionController{
+		Cache:       deps.Cache,
+		KeySet:      deps.KeySet,
+		Revocations: deps.Revocations,
+	}
+	linkController := &controller.LinkController{Cache: deps.Cache, Links: links}
+	fileLinkController := &controller.FileLinkController{Cache: deps.Cache, Links: links}
+	castController := &controller.CastController{Cache: deps.Cache, Session: links}
+	jwksController := &controller.JWKSController{KeySet: deps.KeySet}
+	accessController := &controller.AccessController{}
+
+	tokenAuth := middleware.TokenAuth(deps.Cache, deps.KeySet, deps.Revocations, sessions.ResolveOpaqueToken)
+	publicLinkAuth := middleware.PublicLinkAuth(deps.Cache, links.ResolvePublicAccessContext)
+	fileLinkAuth := middleware.FileLinkAuth(deps.Cache, links.ResolveFileLinkAccessContext)
+	castAuth := middleware.CastAuth(deps.Cache, links.ResolveCastAuthContext)
+
+	oidcGroup := router.Group("/users/oidc")
+	oidcGroup.GET("/:provider/login", oidcController.Login)
+	oidcGroup.GET("/:provider/callback", oidcController.Callback)
+
+	router.POST("/users/logout", tokenAuth, sessionController.Logout)
+	router.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+
+	// Mutations to a link/cast session must be authenticated as the
+	// owning user, not merely as a holder of the link/cast token (which
+	// is also held by anyone the album was shared/cast with). See
+	// LinkController/FileLinkController/CastController's ownership
+	// checks.
+	router.PUT("/collections/share/password", tokenAuth, linkController.ChangePassword)
+	router.POST("/files/share/url/disable", tokenAuth, fileLinkController.Disable)
+	router.POST("/cast/session/terminate", tokenAuth, castController.Terminate)
+
+	// The read paths a public-link/file-link/cast-session token is
+	// actually presented against; this is what makes the cached context
+	// resolvers and RequireScope's resource-ID check reachable from a
+	// real request rather than only exercised by their own tests.
+	router.GET("/public-collection/files/:fileID/thumbnail",
+		publicLinkAuth, auth.RequireScope(ente.ScopeFileThumbnail, auth.FileIDParam("fileID")),
+		accessController.PublicCollectionThumbnail)
+	router.GET("/public-file/:fileID/thumbnail",
+		fileLinkAuth, auth.RequireScope(ente.ScopeFileThumbnail, auth.FileIDParam("fileID")),
+		accessController.FileLinkThumbnail)
+	router.GET("/cast/session/info", castAuth, accessController.CastSessionInfo)
+
+	go deps.KeySet.StartRotationLoop(ctx, keyRotationCheckInterval)
+	auth.BenchmarkArgon2Params()
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package cache provides the small Get/Set/Delete abstraction that the auth
+// middleware sits in front of Postgres with, so that hot lookups like
+// token->userID resolution, public-link context lookup and cast-context
+// lookup don't hit the database on every request.
+package cache
+
+import "time"
+
+// Cache is implemented by both the in-memory default and the Redis-backed
+// implementation. Values are opaque []byte: serializing a value is up to
+// the caller (see auth.WithCache's encode/decode), and both
+// implementations store the bytes exactly as given.
+type Cache interface {
+	// Get returns the cached value and true, or nil and false on a miss
+	// or after the TTL set in Set has elapsed.
+	Get(key string) (value []byte, found bool)
+
+	// Set stores value under key for the given TTL. A TTL of zero means
+	// "use the cache's default TTL".
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Delete explicitly evicts key. Callers must invoke this on logout,
+	// token revocation, public-link password change and cast session
+	// termination rather than waiting for the TTL to lapse, since a
+	// stale positive hit would let a revoked token keep working for up
+	// to the TTL window.
+	Delete(key string) error
+
+	// GetDelete atomically returns key's value (if any) and evicts it in
+	// the same operation, so that two concurrent callers can never both
+	// observe found == true for the same key. Callers that need
+	// single-use semantics (e.g. the OIDC replay store consuming a
+	// state+nonce) must use this instead of a separate Get followed by
+	// Delete, which races.
+	GetDelete(key string) (value []byte, found bool)
+}
+
+// negativeTTL is how long an invalid-token lookup is cached for. It is kept
+// shorter than the positive TTL so that a token which is fixed/retried
+// (e.g. a client that raced a token refresh) isn't negatively cached for
+// long, while still blunting brute-force token-guessing scans.
+const negativeTTL = 5 * time.Second
+
+// DefaultTTL is how long a successful token/context resolution is cached
+// for before the next request re-checks Postgres.
+const DefaultTTL = 30 * time.Second
+
+// tombstone is the value Set stores for a negative cache entry, i.e. "we
+// already know this key resolves to nothing, don't ask Postgres again".
+var tombstone = []byte{0}
+
+// IsNegative reports whether a Get hit was a negative-cache tombstone
+// rather than a real cached value.
+func IsNegative(value []byte) bool {
+	return len(value) == 1 && value[0] == tombstone[0]
+}
+
+// SetNegative records key as known-invalid for negativeTTL.
+func SetNegative(c Cache, key string) error {
+	return c.Set(key, tombstone, negativeTTL)
+}
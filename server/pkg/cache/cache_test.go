@@ -0,0 +1,28 @@
+package cache
+
+import "testing"
+
+func TestSetNegativeIsNegativeRoundTrip(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := SetNegative(c, "k"); err != nil {
+		t.Fatalf("SetNegative failed: %v", err)
+	}
+
+	value, found := c.Get("k")
+	if !found {
+		t.Fatal("expected a hit for a key that was just SetNegative'd")
+	}
+	if !IsNegative(value) {
+		t.Error("expected the cached value to be recognised as a negative-cache tombstone")
+	}
+}
+
+func TestIsNegativeRejectsRealValues(t *testing.T) {
+	if IsNegative([]byte("a real cached value")) {
+		t.Error("a real (non-tombstone) value must not be reported as negative")
+	}
+	if IsNegative(nil) {
+		t.Error("a nil/empty value must not be reported as negative")
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryCache is the default Cache implementation: an in-process map
+// guarded by a mutex, with lazy expiry (an entry past its TTL is treated as
+// a miss on the next Get and dropped). It is the right choice for a single
+// instance; multi-instance deployments that want a shared hit rate should
+// configure the Redis implementation instead.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expireAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{value: value, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryCache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryCache) GetDelete(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	delete(m.entries, key)
+	if !ok || time.Now().After(entry.expireAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheGetSetRoundTrip(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value, found := c.Get("k")
+	if !found {
+		t.Fatal("expected a hit for a key that was just Set")
+	}
+	if string(value) != "v" {
+		t.Errorf("expected %q, got %q", "v", value)
+	}
+}
+
+func TestMemoryCacheGetMiss(t *testing.T) {
+	c := NewMemoryCache()
+	if _, found := c.Get("never-set"); found {
+		t.Error("expected a miss for a key that was never Set")
+	}
+}
+
+func TestMemoryCacheTTLExpiryIsLazilyEvicted(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := c.Set("k", []byte("v"), time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.Get("k"); found {
+		t.Error("expected a miss once the TTL has elapsed")
+	}
+	if _, ok := c.entries["k"]; ok {
+		t.Error("Get should have lazily evicted the expired entry")
+	}
+}
+
+func TestMemoryCacheDelete(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Delete("k"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found := c.Get("k"); found {
+		t.Error("expected a miss after Delete")
+	}
+}
+
+func TestMemoryCacheGetDeleteIsAtomicAndSingleUse(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	value, found := c.GetDelete("k")
+	if !found {
+		t.Fatal("expected GetDelete to find the key that was just Set")
+	}
+	if string(value) != "v" {
+		t.Errorf("expected %q, got %q", "v", value)
+	}
+
+	if _, found := c.GetDelete("k"); found {
+		t.Error("a second GetDelete of the same key should miss, since the first already evicted it")
+	}
+}
+
+func TestMemoryCacheGetDeleteExpired(t *testing.T) {
+	c := NewMemoryCache()
+
+	if err := c.Set("k", []byte("v"), time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.GetDelete("k"); found {
+		t.Error("GetDelete should treat an expired entry as a miss")
+	}
+}
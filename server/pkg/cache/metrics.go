@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Number of auth cache lookups that were served without a DB round-trip.",
+	}, []string{"namespace"})
+
+	cacheMissesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Number of auth cache lookups that fell through to Postgres.",
+	}, []string{"namespace"})
+
+	cacheEvictionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Number of explicit Delete calls (logout, revocation, password change, cast termination) plus GetDelete hits.",
+	}, []string{"namespace"})
+)
+
+// Instrumented wraps a Cache so that every Get/Delete/GetDelete call also
+// updates the cache_{hits,misses,evictions}_total Prometheus counters,
+// labelled by the given namespace (e.g. "token", "public-link", "cast"). A
+// GetDelete hit counts as both a hit and an eviction.
+type Instrumented struct {
+	Cache
+	namespace string
+}
+
+// NewInstrumented wraps c with Prometheus counters under namespace.
+func NewInstrumented(c Cache, namespace string) *Instrumented {
+	return &Instrumented{Cache: c, namespace: namespace}
+}
+
+func (i *Instrumented) Get(key string) ([]byte, bool) {
+	value, found := i.Cache.Get(key)
+	if found {
+		cacheHitsTotal.WithLabelValues(i.namespace).Inc()
+	} else {
+		cacheMissesTotal.WithLabelValues(i.namespace).Inc()
+	}
+	return value, found
+}
+
+func (i *Instrumented) Delete(key string) error {
+	cacheEvictionsTotal.WithLabelValues(i.namespace).Inc()
+	return i.Cache.Delete(key)
+}
+
+func (i *Instrumented) GetDelete(key string) ([]byte, bool) {
+	value, found := i.Cache.GetDelete(key)
+	if found {
+		cacheHitsTotal.WithLabelValues(i.namespace).Inc()
+		cacheEvictionsTotal.WithLabelValues(i.namespace).Inc()
+	} else {
+		cacheMissesTotal.WithLabelValues(i.namespace).Inc()
+	}
+	return value, found
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentedCountsHitsMissesAndEvictions(t *testing.T) {
+	const namespace = "metrics-test-hits-misses-evictions"
+	c := NewInstrumented(NewMemoryCache(), namespace)
+
+	if _, found := c.Get("k"); found {
+		t.Fatal("expected a miss before anything was Set")
+	}
+	if got := testutil.ToFloat64(cacheMissesTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected 1 recorded miss, got %v", got)
+	}
+
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, found := c.Get("k"); !found {
+		t.Fatal("expected a hit after Set")
+	}
+	if got := testutil.ToFloat64(cacheHitsTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected 1 recorded hit, got %v", got)
+	}
+
+	if err := c.Delete("k"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := testutil.ToFloat64(cacheEvictionsTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected 1 recorded eviction from Delete, got %v", got)
+	}
+}
+
+func TestInstrumentedGetDeleteCountsHitAsEviction(t *testing.T) {
+	const namespace = "metrics-test-getdelete"
+	c := NewInstrumented(NewMemoryCache(), namespace)
+
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if _, found := c.GetDelete("k"); !found {
+		t.Fatal("expected GetDelete to find the key that was just Set")
+	}
+	if got := testutil.ToFloat64(cacheHitsTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected 1 recorded hit, got %v", got)
+	}
+	if got := testutil.ToFloat64(cacheEvictionsTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected GetDelete's hit to also record an eviction, got %v", got)
+	}
+
+	if _, found := c.GetDelete("k"); found {
+		t.Fatal("expected a miss on the second GetDelete of an already-evicted key")
+	}
+	if got := testutil.ToFloat64(cacheMissesTotal.WithLabelValues(namespace)); got != 1 {
+		t.Errorf("expected 1 recorded miss, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ente-io/stacktrace"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache is the Cache implementation selected via `cache.backend: redis`
+// in config, for deployments that run more than one museum instance and
+// want them to share a hit rate instead of each keeping its own.
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache wraps an already-configured redis.Client.
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+func (r *RedisCache) Get(key string) ([]byte, bool) {
+	value, err := r.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (r *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if err := r.client.Set(context.Background(), key, value, ttl).Err(); err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+	return nil
+}
+
+func (r *RedisCache) Delete(key string) error {
+	if err := r.client.Del(context.Background(), key).Err(); err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+	return nil
+}
+
+// GetDelete uses Redis's GETDEL, which fetches and deletes key in a single
+// server-side operation, so concurrent callers can't both see found == true.
+func (r *RedisCache) GetDelete(key string) ([]byte, bool) {
+	value, err := r.client.GetDel(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
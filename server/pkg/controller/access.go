@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessController holds the read endpoints that a scoped public-link/
+// file-link/cast-session token is actually checked against: each handler
+// runs behind the matching middleware.PublicLinkAuth/FileLinkAuth/CastAuth
+// (which resolves and caches the token's access context) and, where the
+// request names a specific resource, auth.RequireScope (which enforces the
+// resource-level constraint on that context's ScopeSet).
+type AccessController struct{}
+
+// PublicCollectionThumbnail handles GET
+// /public-collection/files/:fileID/thumbnail, mounted behind
+// middleware.PublicLinkAuth and auth.RequireScope(ente.ScopeFileThumbnail, ...).
+func (ctrl *AccessController) PublicCollectionThumbnail(c *gin.Context) {
+	ctx := auth.MustGetPublicAccessContext(c)
+	c.JSON(http.StatusOK, gin.H{"collectionID": ctx.CollectionID})
+}
+
+// FileLinkThumbnail handles GET /public-file/:fileID/thumbnail, mounted
+// behind middleware.FileLinkAuth and auth.RequireScope(ente.ScopeFileThumbnail, ...).
+func (ctrl *AccessController) FileLinkThumbnail(c *gin.Context) {
+	ctx := auth.MustGetFileLinkAccessContext(c)
+	c.JSON(http.StatusOK, gin.H{"fileID": ctx.FileID})
+}
+
+// CastSessionInfo handles GET /cast/session/info, mounted behind
+// middleware.CastAuth. A cast session always addresses a single paired
+// collection rather than a specific resource, so it's the one access
+// context type here with no RequireScope check alongside it.
+func (ctrl *AccessController) CastSessionInfo(c *gin.Context) {
+	ctx := auth.GetCastCtx(c)
+	c.JSON(http.StatusOK, gin.H{"castSessionID": ctx.CastSessionID})
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/museum/pkg/middleware"
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// CastSessionRepository is the subset of the cast repo that
+// CastController.Terminate needs: checking who owns the collection being
+// cast, and marking the session as ended so it's never resolved from
+// Postgres again even after the cache forgets it.
+type CastSessionRepository interface {
+	GetCastSessionOwnerID(token string) (int64, error)
+	TerminateCastSession(token string) error
+}
+
+// CastController handles ending a cast session (TV unpaired, or the
+// pairing phone ends the session), which must invalidate the cached
+// cast.AuthContext built from the session token.
+type CastController struct {
+	Cache   cache.Cache
+	Session CastSessionRepository
+}
+
+// Terminate handles POST /cast/session/terminate, mounted behind
+// middleware.TokenAuth. Only the owner of the collection being cast may
+// terminate the session; the cast token alone (which the TV holds, and
+// which anyone in the same room could plausibly read off the screen) is
+// not sufficient authorization.
+func (ctrl *CastController) Terminate(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request"})
+		return
+	}
+
+	ownerID, err := ctrl.Session.GetCastSessionOwnerID(req.Token)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if ownerID != auth.GetUserID(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "only the collection owner can terminate this cast session"})
+		return
+	}
+
+	if err := ctrl.Session.TerminateCastSession(req.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := middleware.InvalidateCastSession(ctrl.Cache, req.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cast session terminated"})
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController serves the signing keyset's public keys so that other
+// Ente services (cast receiver, web workers) can verify session JWTs
+// independently, without calling back into museum.
+type JWKSController struct {
+	KeySet *auth.KeySet
+}
+
+// GetJWKS handles GET /.well-known/jwks.json.
+func (ctrl *JWKSController) GetJWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.KeySet.JWKS())
+}
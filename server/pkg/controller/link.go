@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/museum/pkg/middleware"
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkPasswordRepository is the subset of the link repo that
+// LinkController.ChangePassword needs: checking who owns the link's
+// collection, and persisting the new password hash against the link's
+// token.
+type LinkPasswordRepository interface {
+	GetPublicLinkOwnerID(token string) (int64, error)
+	UpdatePublicLinkPassword(token string, hashedPassword string) error
+}
+
+// LinkController handles mutations to public collection links that must
+// invalidate the cached access context built from them, since a stale
+// cache hit would otherwise keep serving the old password/expiry for up
+// to cache.DefaultTTL after the change.
+type LinkController struct {
+	Cache cache.Cache
+	Links LinkPasswordRepository
+}
+
+// ChangePassword handles PUT /collections/share/password, mounted behind
+// middleware.TokenAuth. Only the collection's owner may change its share link's
+// password: the link token itself is known to anyone the album was shared
+// with, so it can't be trusted as authorization on its own. On success the
+// new password is hashed with auth.GetHashedPassword, persisted, and the
+// cached PublicAccessContext for this link is dropped so the next request
+// is forced to re-verify against the new password.
+func (ctrl *LinkController) ChangePassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request"})
+		return
+	}
+
+	ownerID, err := ctrl.Links.GetPublicLinkOwnerID(req.Token)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if ownerID != auth.GetUserID(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "only the collection owner can change this link's password"})
+		return
+	}
+
+	hashed, err := auth.GetHashedPassword(req.Password)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := ctrl.Links.UpdatePublicLinkPassword(req.Token, hashed); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := middleware.InvalidatePublicLink(ctrl.Cache, req.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+// FileLinkRepository is the subset of the link repo that
+// FileLinkController.Disable needs: checking who owns the linked file, and
+// marking the link disabled.
+type FileLinkRepository interface {
+	GetFileLinkOwnerID(token string) (int64, error)
+	DisableFileLink(token string) error
+}
+
+// FileLinkController handles mutations to public file links, mirroring
+// LinkController's ownership check and cache invalidation for the
+// single-file equivalent of a collection share link.
+type FileLinkController struct {
+	Cache cache.Cache
+	Links FileLinkRepository
+}
+
+// Disable handles POST /files/share/url/disable, mounted behind
+// middleware.TokenAuth. Only the file's owner may disable its link.
+func (ctrl *FileLinkController) Disable(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request"})
+		return
+	}
+
+	ownerID, err := ctrl.Links.GetFileLinkOwnerID(req.Token)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if ownerID != auth.GetUserID(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "only the file's owner can disable this link"})
+		return
+	}
+
+	if err := ctrl.Links.DisableFileLink(req.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := middleware.InvalidateFileLink(ctrl.Cache, req.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "file link disabled"})
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ente-io/museum/ente"
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/ente-io/museum/pkg/utils/auth/oidc"
+	"github.com/ente-io/stacktrace"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// KeySetSessionIssuer adapts an auth.KeySet to the SessionIssuer interface,
+// so an OIDC login mints the same kind of JWT session token (see
+// pkg/utils/auth.KeySet.IssueSessionToken) that the JWT-mode email/SRP
+// login flow does. Scopes don't apply to a plain user session, so it's
+// always issued with app ente.Photos and no scope claim.
+type KeySetSessionIssuer struct {
+	KeySet *auth.KeySet
+}
+
+func (s *KeySetSessionIssuer) IssueSessionToken(userID int64) (string, error) {
+	return s.KeySet.IssueSessionToken(userID, ente.Photos, "")
+}
+
+// UserProvisioner is the subset of the user repo that the OIDC controller
+// needs: find the Ente account already linked to an issuer+subject pair,
+// or create one on first login.
+type UserProvisioner interface {
+	GetUserByOIDCSubject(issuer string, subject string) (userID int64, err error)
+	ProvisionOIDCUser(issuer string, subject string, email string) (userID int64, err error)
+	IsEmailDomainAllowed(email string, allowedDomains []string) bool
+}
+
+// SessionIssuer mints the same kind of session token that the email/SRP
+// login flow hands back, so that an OIDC login is indistinguishable to the
+// client from a regular one.
+type SessionIssuer interface {
+	IssueSessionToken(userID int64) (string, error)
+}
+
+// OIDCController handles the /users/oidc/:provider/login and
+// /users/oidc/:provider/callback routes. It never touches a user's E2EE
+// key material; it only ever establishes a session, exactly like the
+// email/SRP flow does.
+type OIDCController struct {
+	Providers   map[string]oidc.Provider
+	Configs     map[string]oidc.Config
+	ReplayStore oidc.ReplayStore
+	Users       UserProvisioner
+	Sessions    SessionIssuer
+}
+
+// loginStateTTL bounds how long a state+nonce pair minted by Login is valid
+// for; a callback arriving after this window is rejected as a replay.
+const loginStateTTL = 10 * time.Minute
+
+// NewOIDCController discovers every configured provider and wires them,
+// together with users and sessions, into a ready-to-mount OIDCController.
+// Called once from pkg/api/router.Setup.
+func NewOIDCController(ctx context.Context, configs []oidc.Config, replayStore oidc.ReplayStore, users UserProvisioner, sessions SessionIssuer) (*OIDCController, error) {
+	providers := make(map[string]oidc.Provider, len(configs))
+	configByName := make(map[string]oidc.Config, len(configs))
+	for _, cfg := range configs {
+		provider, err := oidc.NewProvider(ctx, cfg)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to set up oidc provider %s", cfg.Name)
+		}
+		providers[cfg.Name] = provider
+		configByName[cfg.Name] = cfg
+	}
+
+	return &OIDCController{
+		Providers:   providers,
+		Configs:     configByName,
+		ReplayStore: replayStore,
+		Users:       users,
+		Sessions:    sessions,
+	}, nil
+}
+
+// Login redirects the client to the provider's authorization endpoint.
+func (ctrl *OIDCController) Login(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := ctrl.Providers[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown oidc provider " + name})
+		return
+	}
+
+	state, err := auth.GenerateURLSafeRandomString(32)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+		return
+	}
+	nonce, err := auth.GenerateURLSafeRandomString(32)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+		return
+	}
+	if err := ctrl.ReplayStore.Put(state, nonce, loginStateTTL); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+		return
+	}
+
+	c.Redirect(http.StatusFound, provider.AuthURL(state, nonce))
+}
+
+// Callback verifies the authorization code returned by the provider,
+// provisions an Ente account on first login, and returns a normal session
+// token.
+func (ctrl *OIDCController) Callback(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := ctrl.Providers[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown oidc provider " + name})
+		return
+	}
+
+	state := c.Query("state")
+	nonce, err := ctrl.ReplayStore.Consume(state)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired oidc state"})
+		return
+	}
+
+	idToken, _, err := provider.Exchange(c, c.Query("code"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadGateway, stacktrace.Propagate(err, ""))
+		return
+	}
+
+	claims, err := provider.Verify(c, idToken, nonce)
+	if err != nil {
+		c.AbortWithError(http.StatusUnauthorized, stacktrace.Propagate(err, ""))
+		return
+	}
+
+	cfg := ctrl.Configs[name]
+	if len(cfg.AllowedEmailDomains) > 0 && !ctrl.Users.IsEmailDomainAllowed(claims.Email, cfg.AllowedEmailDomains) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "email domain not allowed for this provider"})
+		return
+	}
+
+	userID, err := ctrl.Users.GetUserByOIDCSubject(claims.Issuer, claims.Subject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// A transient lookup failure (timeout, connection reset, ...) is
+		// not "no such identity" and must not fall through to
+		// provisioning/rejection below, either of which would be wrong:
+		// provisioning risks a duplicate account, rejecting 403s a user
+		// who may well already exist.
+		c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		if !cfg.AutoProvision {
+			c.JSON(http.StatusForbidden, gin.H{"message": "no existing account for this identity, and auto-provisioning is disabled"})
+			return
+		}
+		userID, err = ctrl.Users.ProvisionOIDCUser(claims.Issuer, claims.Subject, claims.Email)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+			return
+		}
+		logrus.WithFields(logrus.Fields{"issuer": claims.Issuer, "userID": userID}).
+			Info("provisioned new user via oidc login")
+	}
+
+	token, err := ctrl.Sessions.IssueSessionToken(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, stacktrace.Propagate(err, ""))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/museum/pkg/middleware"
+	"github.com/ente-io/museum/pkg/utils/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionController handles ending a session: revoking the JWT (if the
+// request's token is one) and dropping the cached token->userID
+// resolution either way, so the logout takes effect on the very next
+// request instead of waiting out the cache's TTL.
+type SessionController struct {
+	Cache       cache.Cache
+	KeySet      *auth.KeySet
+	Revocations auth.RevocationStore
+}
+
+// Logout handles POST /users/logout.
+func (ctrl *SessionController) Logout(c *gin.Context) {
+	token := auth.GetToken(c)
+
+	if auth.IsJWT(token) {
+		if err := auth.RevokeToken(ctrl.KeySet, ctrl.Revocations, token); err != nil {
+			logrus.WithError(err).Warn("failed to revoke session jwt on logout")
+		}
+	}
+
+	if err := middleware.InvalidateToken(ctrl.Cache, token); err != nil {
+		logrus.WithError(err).Warn("failed to invalidate cached token resolution on logout")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
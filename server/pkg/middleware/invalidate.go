@@ -0,0 +1,41 @@
+package middleware
+
+import "github.com/ente-io/museum/pkg/cache"
+
+// The cache namespaces used by TokenAuth/PublicLinkAuth/FileLinkAuth/
+// CastAuth respectively. They are unexported: invalidation call sites
+// (logout, token revocation, public-link password change, cast session
+// termination) go through the Invalidate* functions below, so they don't
+// have to guess the key format auth.WithCache uses.
+const (
+	tokenNamespace      = "token"
+	publicLinkNamespace = "public-link"
+	fileLinkNamespace   = "file-link"
+	castNamespace       = "cast"
+)
+
+// InvalidateToken drops the cached userID resolution for an opaque session
+// token. Call on logout and on "sign out all devices".
+func InvalidateToken(store cache.Cache, token string) error {
+	return store.Delete(tokenNamespace + ":" + token)
+}
+
+// InvalidatePublicLink drops the cached PublicAccessContext for a public
+// collection link token. Call whenever the link's password, expiry, or
+// device limit changes, and when the link is disabled.
+func InvalidatePublicLink(store cache.Cache, token string) error {
+	return store.Delete(publicLinkNamespace + ":" + token)
+}
+
+// InvalidateFileLink drops the cached FileLinkAccessContext for a public
+// file link token. Same call sites as InvalidatePublicLink.
+func InvalidateFileLink(store cache.Cache, token string) error {
+	return store.Delete(fileLinkNamespace + ":" + token)
+}
+
+// InvalidateCastSession drops the cached cast.AuthContext for a cast
+// session token. Call when a cast session is terminated (TV unpaired, or
+// the pairing phone ends the session).
+func InvalidateCastSession(store cache.Cache, token string) error {
+	return store.Delete(castNamespace + ":" + token)
+}
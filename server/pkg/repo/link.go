@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ente-io/museum/ente"
+	"github.com/ente-io/museum/ente/cast"
+	"github.com/ente-io/stacktrace"
+)
+
+// LinkRepository implements controller.LinkPasswordRepository,
+// controller.FileLinkRepository and controller.CastSessionRepository
+// against the public_collection_links/file_links/cast_sessions tables. It
+// also supplies the auth.ContextResolver functions that
+// middleware.PublicLinkAuth/FileLinkAuth/CastAuth sit in front of.
+type LinkRepository struct {
+	DB *sql.DB
+}
+
+// decodeScopeSet parses a link/cast row's persisted scopes column. A NULL/
+// empty column means the row predates scoped tokens; returning a nil
+// ScopeSet lets ente.NewPublicAccessContext (and its FileLinkAccessContext/
+// cast.AuthContext counterparts) promote it to DefaultLinkScope.
+func decodeScopeSet(raw []byte) (ente.ScopeSet, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var scopes ente.ScopeSet
+	if err := json.Unmarshal(raw, &scopes); err != nil {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	return scopes, nil
+}
+
+// ResolvePublicAccessContext looks up the collection and scopes behind a
+// public collection link token, for middleware.PublicLinkAuth.
+func (r *LinkRepository) ResolvePublicAccessContext(token string) (ente.PublicAccessContext, error) {
+	var collectionID int64
+	var scopesJSON []byte
+	if err := r.DB.QueryRow(
+		`SELECT collection_id, scopes FROM public_collection_links WHERE token = $1`,
+		token,
+	).Scan(&collectionID, &scopesJSON); err != nil {
+		return ente.PublicAccessContext{}, stacktrace.Propagate(err, "")
+	}
+	scopes, err := decodeScopeSet(scopesJSON)
+	if err != nil {
+		return ente.PublicAccessContext{}, err
+	}
+	return ente.NewPublicAccessContext(collectionID, scopes), nil
+}
+
+// ResolveFileLinkAccessContext looks up the file and scopes behind a public
+// file link token, for middleware.FileLinkAuth.
+func (r *LinkRepository) ResolveFileLinkAccessContext(token string) (*ente.FileLinkAccessContext, error) {
+	var fileID int64
+	var scopesJSON []byte
+	if err := r.DB.QueryRow(
+		`SELECT file_id, scopes FROM file_links WHERE token = $1 AND NOT disabled`,
+		token,
+	).Scan(&fileID, &scopesJSON); err != nil {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	scopes, err := decodeScopeSet(scopesJSON)
+	if err != nil {
+		return nil, err
+	}
+	return ente.NewFileLinkAccessContext(fileID, scopes), nil
+}
+
+// ResolveCastAuthContext looks up the cast session ID and scopes behind a
+// cast session token, for middleware.CastAuth.
+func (r *LinkRepository) ResolveCastAuthContext(token string) (cast.AuthContext, error) {
+	var castSessionID string
+	var scopesJSON []byte
+	if err := r.DB.QueryRow(
+		`SELECT cast_session_id, scopes FROM cast_sessions WHERE token = $1 AND terminated_at IS NULL`,
+		token,
+	).Scan(&castSessionID, &scopesJSON); err != nil {
+		return cast.AuthContext{}, stacktrace.Propagate(err, "")
+	}
+	scopes, err := decodeScopeSet(scopesJSON)
+	if err != nil {
+		return cast.AuthContext{}, err
+	}
+	return cast.NewAuthContext(castSessionID, scopes), nil
+}
+
+// GetPublicLinkOwnerID resolves the userID that owns the collection behind
+// a public link token, so LinkController.ChangePassword can check the
+// caller's session against it rather than trusting mere possession of the
+// link token.
+func (r *LinkRepository) GetPublicLinkOwnerID(token string) (int64, error) {
+	var ownerID int64
+	if err := r.DB.QueryRow(
+		`SELECT c.owner_id FROM public_collection_links l
+		 JOIN collections c ON c.collection_id = l.collection_id
+		 WHERE l.token = $1`,
+		token,
+	).Scan(&ownerID); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return ownerID, nil
+}
+
+// GetFileLinkOwnerID is GetPublicLinkOwnerID's counterpart for a public
+// file link token, so FileLinkController.Disable can check ownership.
+func (r *LinkRepository) GetFileLinkOwnerID(token string) (int64, error) {
+	var ownerID int64
+	if err := r.DB.QueryRow(
+		`SELECT f.owner_id FROM file_links l
+		 JOIN files f ON f.file_id = l.file_id
+		 WHERE l.token = $1`,
+		token,
+	).Scan(&ownerID); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return ownerID, nil
+}
+
+// GetCastSessionOwnerID is GetPublicLinkOwnerID's counterpart for a cast
+// session token, so CastController.Terminate can check ownership.
+func (r *LinkRepository) GetCastSessionOwnerID(token string) (int64, error) {
+	var ownerID int64
+	if err := r.DB.QueryRow(
+		`SELECT c.owner_id FROM cast_sessions s
+		 JOIN collections c ON c.collection_id = s.collection_id
+		 WHERE s.token = $1`,
+		token,
+	).Scan(&ownerID); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return ownerID, nil
+}
+
+// UpdatePublicLinkPassword persists a newly-hashed password against the
+// public link identified by token.
+func (r *LinkRepository) UpdatePublicLinkPassword(token string, hashedPassword string) error {
+	if _, err := r.DB.Exec(
+		`UPDATE public_collection_links SET password_hash = $1 WHERE token = $2`,
+		hashedPassword, token,
+	); err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+	return nil
+}
+
+// DisableFileLink marks a public file link as disabled, so it's never
+// resolved again even after the cache has forgotten it.
+func (r *LinkRepository) DisableFileLink(token string) error {
+	if _, err := r.DB.Exec(
+		`UPDATE file_links SET disabled = true WHERE token = $1`,
+		token,
+	); err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+	return nil
+}
+
+// TerminateCastSession marks a cast session as ended so it's never
+// resolved from Postgres again, even after the cache has forgotten it.
+func (r *LinkRepository) TerminateCastSession(token string) error {
+	if _, err := r.DB.Exec(
+		`UPDATE cast_sessions SET terminated_at = now() WHERE token = $1`,
+		token,
+	); err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+	return nil
+}
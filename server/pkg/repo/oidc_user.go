@@ -0,0 +1,80 @@
+// Package repo holds the Postgres-backed repositories that controllers
+// depend on.
+package repo
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/ente-io/stacktrace"
+)
+
+// OIDCUserRepository implements controller.UserProvisioner against the
+// users table, keyed by issuer+subject rather than email/SRP.
+type OIDCUserRepository struct {
+	DB *sql.DB
+}
+
+// GetUserByOIDCSubject looks up the Ente user previously provisioned for
+// this issuer+subject pair. Returns sql.ErrNoRows (wrapped) if none exists
+// yet, i.e. this is the identity's first login.
+func (r *OIDCUserRepository) GetUserByOIDCSubject(issuer string, subject string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRow(
+		`SELECT user_id FROM oidc_identities WHERE issuer = $1 AND subject = $2`,
+		issuer, subject,
+	).Scan(&userID)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return userID, nil
+}
+
+// ProvisionOIDCUser creates a new Ente user for an issuer+subject seen for
+// the first time, and records the issuer+subject -> user_id mapping so
+// future logins from the same identity resolve via GetUserByOIDCSubject.
+func (r *OIDCUserRepository) ProvisionOIDCUser(issuer string, subject string, email string) (int64, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var userID int64
+	if err := tx.QueryRow(
+		`INSERT INTO users (email) VALUES ($1) RETURNING user_id`,
+		email,
+	).Scan(&userID); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO oidc_identities (user_id, issuer, subject) VALUES ($1, $2, $3)`,
+		userID, issuer, subject,
+	); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return userID, nil
+}
+
+// IsEmailDomainAllowed reports whether email's domain is in allowedDomains.
+// An empty allowedDomains (checked by the caller before calling this)
+// means "no restriction"; this only ever gets called when the provider's
+// config actually sets one.
+func (r *OIDCUserRepository) IsEmailDomainAllowed(email string, allowedDomains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range allowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"database/sql"
+
+	"github.com/ente-io/stacktrace"
+)
+
+// SessionRepository implements the legacy opaque-token-to-userID lookup
+// that middleware.TokenAuth falls back to for tokens that aren't JWTs.
+type SessionRepository struct {
+	DB *sql.DB
+}
+
+// ResolveOpaqueToken looks up the userID for a still-valid opaque session
+// token.
+func (r *SessionRepository) ResolveOpaqueToken(token string) (int64, error) {
+	var userID int64
+	err := r.DB.QueryRow(
+		`SELECT user_id FROM tokens WHERE token = $1 AND (expires_at IS NULL OR expires_at > now())`,
+		token,
+	).Scan(&userID)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return userID, nil
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/ente-io/stacktrace"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -61,26 +60,6 @@ func GenerateURLSafeRandomString(s int) (string, error) {
 	return base64.URLEncoding.EncodeToString(b), stacktrace.Propagate(err, "")
 }
 
-// GetHashedPassword returns the has of a specified password
-func GetHashedPassword(password string) (string, error) {
-	saltedBytes := []byte(password)
-	hashedBytes, err := bcrypt.GenerateFromPassword(saltedBytes, bcrypt.DefaultCost)
-	if err != nil {
-		return "", stacktrace.Propagate(err, "")
-	}
-
-	hash := string(hashedBytes[:])
-	return hash, nil
-}
-
-// CompareHashes compares a bcrypt hashed password with its possible plaintext
-// equivalent. Returns nil on success, or an error on failure.
-func CompareHashes(hash string, s string) error {
-	existing := []byte(hash)
-	incoming := []byte(s)
-	return bcrypt.CompareHashAndPassword(existing, incoming)
-}
-
 // GetUserID fetches the userID from context and validates against header
 // If context value is missing, logs warning and uses header value
 // If values differ, returns an error
@@ -158,6 +137,20 @@ func GetToken(c *gin.Context) string {
 	return token
 }
 
+// GetBearerToken fetches the bearer token from the Authorization header, or
+// returns "" if there is no "Bearer " header. ResolveToken and
+// middleware.TokenAuth read only X-Auth-Token (see GetToken), and an OIDC
+// login (see pkg/utils/auth/oidc) ends by handing back a regular session
+// token; nothing in this package verifies a bearer token.
+func GetBearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	const prefix = "Bearer "
+	if strings.HasPrefix(header, prefix) {
+		return strings.TrimPrefix(header, prefix)
+	}
+	return ""
+}
+
 func GetAccessToken(c *gin.Context) string {
 	token := c.GetHeader("X-Auth-Access-Token")
 	if token == "" {
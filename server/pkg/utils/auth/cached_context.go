@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/ente-io/museum/pkg/cache"
+)
+
+// errCachedInvalidToken is returned when a token resolves to a negative
+// cache entry, i.e. a previous lookup already established it's invalid.
+var errCachedInvalidToken = errors.New("token is cached as invalid")
+
+// ContextResolver is whatever currently does the DB lookup from a raw
+// token to the access context (public-link, file-link, cast) that gets
+// stashed into the gin context. It is the thing the cache sits in front of.
+type ContextResolver[T any] func(token string) (T, error)
+
+// WithCache wraps a ContextResolver so that repeated lookups for the same
+// token within cache.DefaultTTL skip the DB entirely, including a shorter
+// negative-cache TTL for invalid tokens so that brute-force scans against
+// a single endpoint don't each cost a Postgres round trip.
+//
+// encode/decode let the caller plug in how a T is serialized for the cache
+// backend (Redis needs bytes; the in-memory backend would be happy with a
+// no-op encode/decode pair, but we keep the signature uniform so switching
+// backends via config never requires touching call sites).
+func WithCache[T any](
+	store cache.Cache,
+	namespace string,
+	resolve ContextResolver[T],
+	encode func(T) []byte,
+	decode func([]byte) (T, error),
+) ContextResolver[T] {
+	return func(token string) (T, error) {
+		key := namespace + ":" + token
+		if cached, found := store.Get(key); found {
+			var zero T
+			if cache.IsNegative(cached) {
+				return zero, errCachedInvalidToken
+			}
+			return decode(cached)
+		}
+
+		value, err := resolve(token)
+		if err != nil {
+			_ = cache.SetNegative(store, key)
+			return value, err
+		}
+
+		_ = store.Set(key, encode(value), cache.DefaultTTL)
+		return value, nil
+	}
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ente-io/museum/ente"
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/stacktrace"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// sessionClaims are the claims embedded in a signed session JWT. Unlike the
+// opaque X-Auth-Token, a JWT can be verified by any service that has the
+// current JWKS (museum, cast receiver, web workers) without a DB round trip.
+type sessionClaims struct {
+	jwt.RegisteredClaims
+	App   ente.App `json:"app"`
+	Scope string   `json:"scope,omitempty"`
+}
+
+// signingKey is one entry in the rotating keyset: a kid, its keypair, and
+// expiresAt. While the key is KeySet.active (the one key that signs new
+// tokens), expiresAt is its mint time plus rotationInterval plus
+// tokenLifetime; once it is retired to KeySet.history, where it only
+// verifies tokens signed before the last rotation, expiresAt is reset to
+// tokenLifetime past the retirement.
+type signingKey struct {
+	kid       string
+	public    ed25519.PublicKey
+	private   ed25519.PrivateKey
+	expiresAt time.Time
+}
+
+// KeySet is the rotating EdDSA keyset that session JWTs are signed and
+// verified against. An active signing key plus N previous verification
+// keys are kept in memory; rotation drops keys whose tokens could no longer
+// possibly still be valid (i.e. older than the token lifetime).
+type KeySet struct {
+	mu      sync.RWMutex
+	active  *signingKey
+	history []*signingKey
+
+	rotationInterval time.Duration
+	tokenLifetime    time.Duration
+}
+
+// NewKeySet creates a keyset with rotationInterval between signing key
+// rotations (the default is 24h) and tokenLifetime as the lifetime of an
+// individual issued token; previous keys are retained for at least
+// tokenLifetime past their retirement so in-flight tokens keep verifying.
+func NewKeySet(rotationInterval time.Duration, tokenLifetime time.Duration) (*KeySet, error) {
+	ks := &KeySet{rotationInterval: rotationInterval, tokenLifetime: tokenLifetime}
+	if err := ks.rotate(); err != nil {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	return ks, nil
+}
+
+// rotate generates a fresh signing key, demotes the previous active key to
+// history, and drops any history entries old enough that every token they
+// ever signed has since expired.
+func (ks *KeySet) rotate() error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+
+	kid, err := GenerateURLSafeRandomString(8)
+	if err != nil {
+		return stacktrace.Propagate(err, "")
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	newKey := &signingKey{kid: kid, public: public, private: private, expiresAt: time.Now().Add(ks.rotationInterval + ks.tokenLifetime)}
+
+	if ks.active != nil {
+		// Tokens the retiring key signed up to now stay valid for up to
+		// tokenLifetime, however late this rotation runs.
+		ks.active.expiresAt = time.Now().Add(ks.tokenLifetime)
+		ks.history = append(ks.history, ks.active)
+	}
+	ks.active = newKey
+
+	live := ks.history[:0]
+	for _, k := range ks.history {
+		if time.Now().Before(k.expiresAt) {
+			live = append(live, k)
+		}
+	}
+	ks.history = live
+
+	return nil
+}
+
+// MaybeRotate rotates the active signing key if rotationInterval has
+// elapsed since it was minted. Intended to be called periodically (e.g.
+// from a background ticker) rather than relying on process restarts.
+func (ks *KeySet) MaybeRotate() error {
+	ks.mu.RLock()
+	due := ks.active == nil || time.Now().After(ks.active.expiresAt.Add(-ks.tokenLifetime))
+	ks.mu.RUnlock()
+	if !due {
+		return nil
+	}
+	return ks.rotate()
+}
+
+// StartRotationLoop calls MaybeRotate on a ticker (checking every
+// checkInterval, which should be well under rotationInterval) until ctx is
+// done. This is what actually makes "keys rotate on a configurable
+// interval" true in a running process, as opposed to only on restart; call
+// it once from server startup.
+func (ks *KeySet) StartRotationLoop(ctx context.Context, checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.MaybeRotate(); err != nil {
+				logrus.WithError(err).Error("failed to rotate jwt signing key")
+			}
+		}
+	}
+}
+
+func (ks *KeySet) signingKeyFor() *signingKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+func (ks *KeySet) verificationKey(kid string) (ed25519.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.active != nil && ks.active.kid == kid {
+		return ks.active.public, true
+	}
+	for _, k := range ks.history {
+		if k.kid == kid {
+			return k.public, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS renders the keyset's public keys in the standard JWKS document shape,
+// for serving at /.well-known/jwks.json.
+func (ks *KeySet) JWKS() map[string]interface{} {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]map[string]interface{}, 0, len(ks.history)+1)
+	add := func(k *signingKey) {
+		keys = append(keys, map[string]interface{}{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"kid": k.kid,
+			"x":   base64RawURL(k.public),
+			"use": "sig",
+			"alg": "EdDSA",
+		})
+	}
+	if ks.active != nil {
+		add(ks.active)
+	}
+	for _, k := range ks.history {
+		add(k)
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// IssueSessionToken mints a signed session JWT for userID, valid for the
+// keyset's configured tokenLifetime. scope, if non-empty, is carried as the
+// token's `scope` claim (see ScopeSet.String).
+func (ks *KeySet) IssueSessionToken(userID int64, app ente.App, scope string) (string, error) {
+	key := ks.signingKeyFor()
+	if key == nil {
+		return "", stacktrace.NewError("no active signing key")
+	}
+
+	jti, err := GenerateURLSafeRandomString(16)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "")
+	}
+
+	now := time.Now()
+	claims := sessionClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   formatUserID(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ks.tokenLifetime)),
+			ID:        jti,
+		},
+		App:   app,
+		Scope: scope,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.kid
+
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "")
+	}
+	return signed, nil
+}
+
+// RevocationStore is the jti deny-list that backs "logout" and "sign out
+// all devices": ParseToken consults it before trusting an otherwise
+// validly-signed token.
+type RevocationStore interface {
+	Revoke(jti string, ttl time.Duration) error
+	IsRevoked(jti string) bool
+}
+
+// cacheRevocationStore is the default RevocationStore, backed by the same
+// cache.Cache the rest of the auth middleware uses (in-memory or Redis,
+// per config). The TTL it stores a revocation for is always the token's
+// remaining lifetime, since there is no point remembering a revoked jti
+// past the point where the token would have expired anyway.
+type cacheRevocationStore struct {
+	store cache.Cache
+}
+
+// NewCacheRevocationStore wraps store as a RevocationStore.
+func NewCacheRevocationStore(store cache.Cache) RevocationStore {
+	return &cacheRevocationStore{store: store}
+}
+
+func (c *cacheRevocationStore) Revoke(jti string, ttl time.Duration) error {
+	return c.store.Set("revoked-jti:"+jti, []byte{1}, ttl)
+}
+
+func (c *cacheRevocationStore) IsRevoked(jti string) bool {
+	_, found := c.store.Get("revoked-jti:" + jti)
+	return found
+}
+
+// parseClaims verifies a session JWT's signature against ks and checks its
+// standard claims (expiry), without consulting the revocation store or
+// Postgres.
+func parseClaims(ks *KeySet, tokenString string) (*sessionClaims, error) {
+	var claims sessionClaims
+	parsed, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := ks.verificationKey(kid)
+		if !ok {
+			return nil, stacktrace.NewError("unknown signing key %s", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil || !parsed.Valid {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	return &claims, nil
+}
+
+// ParseToken verifies a session JWT's signature against ks, checks
+// standard claims (expiry) and consults revocations for the jti, without
+// ever touching Postgres.
+func ParseToken(ks *KeySet, revocations RevocationStore, tokenString string) (userID int64, app ente.App, scope string, err error) {
+	claims, err := parseClaims(ks, tokenString)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	if revocations.IsRevoked(claims.ID) {
+		return 0, "", "", stacktrace.NewError("token has been revoked")
+	}
+
+	userID, err = parseUserID(claims.Subject)
+	if err != nil {
+		return 0, "", "", stacktrace.Propagate(err, "")
+	}
+	return userID, claims.App, claims.Scope, nil
+}
+
+// RevokeToken marks a session JWT's jti as revoked for the remainder of
+// its lifetime. Called on logout and "sign out all devices" so that a
+// token which is otherwise still validly signed stops being accepted by
+// ParseToken immediately, rather than waiting out its exp.
+func RevokeToken(ks *KeySet, revocations RevocationStore, tokenString string) error {
+	claims, err := parseClaims(ks, tokenString)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return revocations.Revoke(claims.ID, ttl)
+}
+
+// IsJWT reports whether token looks like a JWT (three dot-separated
+// base64url segments) as opposed to the opaque session tokens the
+// email/SRP flow has always issued. ResolveToken uses this to decide which
+// verification path a request should take.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// ResolveToken decides, based on the shape of the request's X-Auth-Token,
+// whether to verify it as a session JWT (no DB hit) or fall back to
+// legacyResolve, the existing opaque-token-to-userID DB lookup. Existing
+// clients with an opaque token keep working unchanged; a client that has
+// been issued a JWT (see KeySet.IssueSessionToken) is verified entirely
+// from the JWKS.
+func ResolveToken(c *gin.Context, ks *KeySet, revocations RevocationStore, legacyResolve func(token string) (int64, error)) (int64, error) {
+	token := GetToken(c)
+	if !IsJWT(token) {
+		return legacyResolve(token)
+	}
+
+	userID, _, _, err := ParseToken(ks, revocations, token)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return userID, nil
+}
+
+func formatUserID(userID int64) string {
+	return strconv.FormatInt(userID, 10)
+}
+
+func parseUserID(subject string) (int64, error) {
+	userID, err := strconv.ParseInt(subject, 10, 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "")
+	}
+	return userID, nil
+}
+
+func base64RawURL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ente-io/museum/ente"
+	"github.com/ente-io/museum/pkg/cache"
+)
+
+func TestIssueAndParseSessionToken(t *testing.T) {
+	ks, err := NewKeySet(24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	revocations := NewCacheRevocationStore(cache.NewMemoryCache())
+
+	token, err := ks.IssueSessionToken(42, ente.Auth, "")
+	if err != nil {
+		t.Fatalf("IssueSessionToken failed: %v", err)
+	}
+	if !IsJWT(token) {
+		t.Fatal("a freshly issued session token should look like a JWT")
+	}
+
+	userID, app, _, err := ParseToken(ks, revocations, token)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if userID != 42 {
+		t.Errorf("expected userID 42, got %d", userID)
+	}
+	if app != ente.Auth {
+		t.Errorf("expected app %q, got %q", ente.Auth, app)
+	}
+}
+
+func TestParseTokenRejectsRevoked(t *testing.T) {
+	ks, err := NewKeySet(24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	revocations := NewCacheRevocationStore(cache.NewMemoryCache())
+
+	token, err := ks.IssueSessionToken(1, ente.Photos, "")
+	if err != nil {
+		t.Fatalf("IssueSessionToken failed: %v", err)
+	}
+
+	if _, _, _, err := ParseToken(ks, revocations, token); err != nil {
+		t.Fatalf("expected the token to verify before revocation: %v", err)
+	}
+
+	if err := RevokeToken(ks, revocations, token); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+
+	if _, _, _, err := ParseToken(ks, revocations, token); err == nil {
+		t.Error("ParseToken should reject a revoked token")
+	}
+}
+
+func TestParseTokenRejectsUnknownSigningKey(t *testing.T) {
+	ks1, err := NewKeySet(24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	ks2, err := NewKeySet(24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	revocations := NewCacheRevocationStore(cache.NewMemoryCache())
+
+	token, err := ks1.IssueSessionToken(1, ente.Photos, "")
+	if err != nil {
+		t.Fatalf("IssueSessionToken failed: %v", err)
+	}
+
+	if _, _, _, err := ParseToken(ks2, revocations, token); err == nil {
+		t.Error("a token signed by one keyset should not verify against an unrelated keyset")
+	}
+}
+
+func TestMaybeRotateRotatesOnlyWhenDue(t *testing.T) {
+	ks, err := NewKeySet(time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+	kidBefore := ks.signingKeyFor().kid
+
+	if err := ks.MaybeRotate(); err != nil {
+		t.Fatalf("MaybeRotate failed: %v", err)
+	}
+	if ks.signingKeyFor().kid != kidBefore {
+		t.Error("MaybeRotate should not rotate before the rotation interval has elapsed")
+	}
+
+	// Force the active key into the past so MaybeRotate sees it as due.
+	ks.mu.Lock()
+	ks.active.expiresAt = time.Now().Add(-time.Minute)
+	ks.mu.Unlock()
+
+	if err := ks.MaybeRotate(); err != nil {
+		t.Fatalf("MaybeRotate failed: %v", err)
+	}
+	if ks.signingKeyFor().kid == kidBefore {
+		t.Error("MaybeRotate should rotate once the active key is past due")
+	}
+
+	// The retired key must still verify tokens it already signed.
+	if _, ok := ks.verificationKey(kidBefore); !ok {
+		t.Error("a just-retired key should still be kept around for verification")
+	}
+}
+
+func TestStartRotationLoopStopsOnContextDone(t *testing.T) {
+	ks, err := NewKeySet(time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ks.StartRotationLoop(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StartRotationLoop did not return after its context was cancelled")
+	}
+}
@@ -0,0 +1,140 @@
+// Package oidc lets Ente accept sign-ins from external OIDC/OAuth2 identity
+// providers (Google, GitHub, self-hosted Keycloak/Dex/Ory) in addition to
+// the existing email/SRP flow.
+//
+// Because Ente is end-to-end encrypted, authenticating via a Provider only
+// establishes a session: it never grants access to a user's E2EE key
+// material. The client still has to unlock the master key with the user's
+// passphrase after the server hands back a session token.
+package oidc
+
+import (
+	"context"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ente-io/stacktrace"
+	"golang.org/x/oauth2"
+)
+
+// Claims is the subset of an ID token's claims that Ente cares about when
+// provisioning or matching an existing user.
+type Claims struct {
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+}
+
+// Provider is a single configured identity provider (e.g. "google",
+// "github", or a self-hosted Keycloak/Dex/Ory realm). Implementations wrap
+// github.com/coreos/go-oidc for discovery, JWKS caching and ID token
+// verification.
+type Provider interface {
+	// Name is the path segment used in /users/oidc/:provider/login.
+	Name() string
+
+	// AuthURL returns the provider's authorization endpoint URL that the
+	// client should redirect the user to, embedding the given state so
+	// the callback can be matched back to this login attempt, and the
+	// given nonce so the returned ID token can be checked against replay.
+	AuthURL(state string, nonce string) string
+
+	// Exchange trades an authorization code for tokens.
+	Exchange(ctx context.Context, code string) (idToken string, accessToken string, err error)
+
+	// Verify checks the ID token's signature, issuer, audience and expiry
+	// against the provider's published JWKS, checks its nonce claim
+	// against expectedNonce (the nonce minted by AuthURL for this login
+	// attempt, so a captured/replayed ID token can't be reused for a
+	// different one), and returns the claims that were inside it.
+	Verify(ctx context.Context, idToken string, expectedNonce string) (*Claims, error)
+}
+
+// Config is the per-provider configuration loaded from the `oidc.providers`
+// YAML block.
+type Config struct {
+	Name                string   `yaml:"name"`
+	IssuerURL           string   `yaml:"issuer_url"`
+	ClientID            string   `yaml:"client_id"`
+	ClientSecret        string   `yaml:"client_secret"`
+	RedirectURL         string   `yaml:"redirect_url"`
+	AllowedEmailDomains []string `yaml:"allowed_email_domains"`
+	AutoProvision       bool     `yaml:"auto_provision"`
+}
+
+// provider is the default Provider implementation, backed by
+// github.com/coreos/go-oidc for discovery and JWKS caching.
+type provider struct {
+	cfg      Config
+	verifier *gooidc.IDTokenVerifier
+	oauth2   oauth2.Config
+}
+
+// NewProvider discovers the issuer's OIDC configuration (authorization,
+// token and JWKS endpoints) and returns a Provider ready to handle logins.
+// Discovery results, including the JWKS, are cached and refreshed by the
+// underlying go-oidc provider so repeated verifications don't re-fetch them.
+func NewProvider(ctx context.Context, cfg Config) (Provider, error) {
+	issuer, err := gooidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to discover oidc issuer %s", cfg.IssuerURL)
+	}
+
+	return &provider{
+		cfg:      cfg,
+		verifier: issuer.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     issuer.Endpoint(),
+			Scopes:       []string{gooidc.ScopeOpenID, "email", "profile"},
+		},
+	}, nil
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *provider) AuthURL(state string, nonce string) string {
+	return p.oauth2.AuthCodeURL(state, gooidc.Nonce(nonce))
+}
+
+func (p *provider) Exchange(ctx context.Context, code string) (string, string, error) {
+	token, err := p.oauth2.Exchange(ctx, code)
+	if err != nil {
+		return "", "", stacktrace.Propagate(err, "")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", "", stacktrace.NewError("token response from %s did not include an id_token", p.cfg.Name)
+	}
+	return rawIDToken, token.AccessToken, nil
+}
+
+func (p *provider) Verify(ctx context.Context, idToken string, expectedNonce string) (*Claims, error) {
+	token, err := p.verifier.Verify(ctx, idToken)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	var claims Claims
+	if err := token.Claims(&claims); err != nil {
+		return nil, stacktrace.Propagate(err, "")
+	}
+	if claims.Nonce == "" || claims.Nonce != expectedNonce {
+		return nil, stacktrace.NewError("id token nonce does not match the nonce issued for this login attempt")
+	}
+	return &claims, nil
+}
+
+// ReplayStore tracks the state+nonce pairs issued for in-flight login
+// attempts so that a callback can only be consumed once, within a short
+// TTL. The implementation in this package is the cache.Cache-backed one
+// returned by NewCacheReplayStore (see replay.go).
+type ReplayStore interface {
+	Put(state string, nonce string, ttl time.Duration) error
+	Consume(state string) (nonce string, err error)
+}
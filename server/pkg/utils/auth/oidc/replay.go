@@ -0,0 +1,35 @@
+package oidc
+
+import (
+	"time"
+
+	"github.com/ente-io/museum/pkg/cache"
+	"github.com/ente-io/stacktrace"
+)
+
+// cacheReplayStore is the default ReplayStore, backed by the shared
+// cache.Cache (in-memory or Redis, per config). A state can only ever be
+// Consume'd once: Consume fetches and evicts the entry via GetDelete in a
+// single atomic operation, so two concurrent callbacks racing the same
+// state can't both observe it as present (a separate Get followed by
+// Delete would let exactly that happen).
+type cacheReplayStore struct {
+	store cache.Cache
+}
+
+// NewCacheReplayStore wraps store as a ReplayStore.
+func NewCacheReplayStore(store cache.Cache) ReplayStore {
+	return &cacheReplayStore{store: store}
+}
+
+func (c *cacheReplayStore) Put(state string, nonce string, ttl time.Duration) error {
+	return c.store.Set("oidc-state:"+state, []byte(nonce), ttl)
+}
+
+func (c *cacheReplayStore) Consume(state string) (string, error) {
+	value, found := c.store.GetDelete("oidc-state:" + state)
+	if !found {
+		return "", stacktrace.NewError("unknown or expired oidc state")
+	}
+	return string(value), nil
+}
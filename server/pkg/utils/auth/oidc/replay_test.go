@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ente-io/museum/pkg/cache"
+)
+
+func TestCacheReplayStoreConsumeReturnsStoredNonce(t *testing.T) {
+	store := NewCacheReplayStore(cache.NewMemoryCache())
+
+	if err := store.Put("state-1", "nonce-1", time.Minute); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	nonce, err := store.Consume("state-1")
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if nonce != "nonce-1" {
+		t.Errorf("expected nonce-1, got %q", nonce)
+	}
+}
+
+func TestCacheReplayStoreConsumeIsSingleUse(t *testing.T) {
+	store := NewCacheReplayStore(cache.NewMemoryCache())
+
+	if err := store.Put("state-1", "nonce-1", time.Minute); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := store.Consume("state-1"); err != nil {
+		t.Fatalf("first Consume failed: %v", err)
+	}
+
+	if _, err := store.Consume("state-1"); err == nil {
+		t.Error("a second Consume of the same state must fail, since that's the replay it exists to prevent")
+	}
+}
+
+func TestCacheReplayStoreConsumeIsAtomicUnderConcurrency(t *testing.T) {
+	store := NewCacheReplayStore(cache.NewMemoryCache())
+
+	if err := store.Put("state-1", "nonce-1", time.Minute); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	const racers = 50
+	var wg sync.WaitGroup
+	var successes int
+	var mu sync.Mutex
+	wg.Add(racers)
+	for i := 0; i < racers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := store.Consume("state-1"); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Errorf("expected exactly 1 of %d concurrent Consume calls to succeed, got %d", racers, successes)
+	}
+}
+
+func TestCacheReplayStoreConsumeUnknownState(t *testing.T) {
+	store := NewCacheReplayStore(cache.NewMemoryCache())
+
+	if _, err := store.Consume("never-issued"); err == nil {
+		t.Error("Consume of a state that was never Put should fail")
+	}
+}
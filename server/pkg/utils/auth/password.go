@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ente-io/stacktrace"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// argon2Params are the cost parameters Argon2id is run with. They're baked
+// into every hash via the PHC string (see encodeArgon2Hash), so changing
+// these only affects newly-minted hashes; existing ones keep verifying
+// against whatever parameters they were created with.
+type argon2Params struct {
+	Memory      uint32 // KiB
+	Time        uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// defaultArgon2Params matches the OWASP-recommended baseline: 64MB,
+// 3 iterations, 2 threads of parallelism.
+var defaultArgon2Params = argon2Params{
+	Memory:      64 * 1024,
+	Time:        3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// loadArgon2Params reads hash.argon2.{memory-mb,time,parallelism} from
+// config, falling back to defaultArgon2Params for anything unset.
+func loadArgon2Params() argon2Params {
+	params := defaultArgon2Params
+	if viper.IsSet("hash.argon2.memory-mb") {
+		params.Memory = uint32(viper.GetInt("hash.argon2.memory-mb")) * 1024
+	}
+	if viper.IsSet("hash.argon2.time") {
+		params.Time = uint32(viper.GetInt("hash.argon2.time"))
+	}
+	if viper.IsSet("hash.argon2.parallelism") {
+		params.Parallelism = uint8(viper.GetInt("hash.argon2.parallelism"))
+	}
+	return params
+}
+
+// BenchmarkArgon2Params hashes a throwaway password with the configured
+// parameters and logs a warning if it takes suspiciously little time,
+// since that usually means the memory/time cost was configured too low to
+// be a meaningful deterrent against GPU/ASIC attacks. Call this once at
+// startup.
+func BenchmarkArgon2Params() {
+	params := loadArgon2Params()
+	start := time.Now()
+	argon2.IDKey([]byte("benchmark"), make([]byte, params.SaltLength), params.Time, params.Memory, params.Parallelism, params.KeyLength)
+	elapsed := time.Since(start)
+	if elapsed < 50*time.Millisecond {
+		logrus.WithField("elapsed", elapsed).
+			Warn("argon2id hash took under 50ms with the configured cost parameters; consider raising hash.argon2.memory-mb or hash.argon2.time")
+	}
+}
+
+// GetHashedPassword hashes password with Argon2id, using the configured (or
+// default) cost parameters, and returns it as a self-describing PHC string:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func GetHashedPassword(password string) (string, error) {
+	params := loadArgon2Params()
+
+	salt, err := GenerateRandomBytes(int(params.SaltLength))
+	if err != nil {
+		return "", stacktrace.Propagate(err, "")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, params.KeyLength)
+
+	return encodeArgon2Hash(params, salt, key), nil
+}
+
+// CompareHashes compares a stored password hash with its possible plaintext
+// equivalent. It dispatches on the hash's prefix: "$argon2id$..." hashes are
+// parsed and verified with a constant-time comparison; anything else is
+// assumed to be a legacy bcrypt hash. Returns nil on success, or an error on
+// failure.
+func CompareHashes(hash string, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return compareArgon2Hash(hash, password)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// NeedsRehash reports whether hash should be replaced with a fresh
+// GetHashedPassword result on the next successful login: either because
+// it's still a bcrypt hash, or because it's an Argon2id hash whose cost
+// parameters are weaker than what's currently configured. Callers follow
+// the standard pattern of `if NeedsRehash(stored) { rehash and persist }`
+// immediately after CompareHashes succeeds.
+func NeedsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return true
+	}
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		// Unparseable argon2id hash; safest is to force a rehash.
+		return true
+	}
+	current := loadArgon2Params()
+	return params.Memory < current.Memory || params.Time < current.Time || params.Parallelism < current.Parallelism
+}
+
+func encodeArgon2Hash(params argon2Params, salt []byte, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory, params.Time, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	var version int
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, stacktrace.NewError("malformed argon2id hash")
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, stacktrace.Propagate(err, "")
+	}
+	if version != argon2.Version {
+		return params, nil, nil, stacktrace.NewError("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return params, nil, nil, stacktrace.Propagate(err, "")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, stacktrace.Propagate(err, "")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, stacktrace.Propagate(err, "")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+func compareArgon2Hash(hash string, password string) error {
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return err
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return stacktrace.NewError("password does not match")
+	}
+	return nil
+}
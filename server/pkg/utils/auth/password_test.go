@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGetHashedPasswordRoundTrip(t *testing.T) {
+	hash, err := GetHashedPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("GetHashedPassword failed: %v", err)
+	}
+
+	if err := CompareHashes(hash, "correct horse battery staple"); err != nil {
+		t.Errorf("CompareHashes rejected the correct password: %v", err)
+	}
+	if err := CompareHashes(hash, "wrong password"); err == nil {
+		t.Error("CompareHashes accepted an incorrect password")
+	}
+}
+
+func TestDecodeArgon2HashRejectsMalformed(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"$argon2id$v=19$m=65536,t=3,p=2$onlyfiveparts",
+		"$bcrypt$v=19$m=65536,t=3,p=2$c2FsdA$a2V5",
+	} {
+		if _, _, _, err := decodeArgon2Hash(hash); err == nil {
+			t.Errorf("expected decodeArgon2Hash(%q) to fail", hash)
+		}
+	}
+}
+
+func TestCompareArgon2HashRoundTrip(t *testing.T) {
+	hash, err := GetHashedPassword("s3cret")
+	if err != nil {
+		t.Fatalf("GetHashedPassword failed: %v", err)
+	}
+	if err := compareArgon2Hash(hash, "s3cret"); err != nil {
+		t.Errorf("compareArgon2Hash rejected the correct password: %v", err)
+	}
+	if err := compareArgon2Hash(hash, "not-s3cret"); err == nil {
+		t.Error("compareArgon2Hash accepted an incorrect password")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := GetHashedPassword("s3cret")
+	if err != nil {
+		t.Fatalf("GetHashedPassword failed: %v", err)
+	}
+	if NeedsRehash(hash) {
+		t.Error("a freshly minted hash at current cost parameters should not need a rehash")
+	}
+
+	weak := encodeArgon2Hash(argon2Params{Memory: 1024, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, make([]byte, 16), make([]byte, 32))
+	if !NeedsRehash(weak) {
+		t.Error("a hash with weaker-than-configured cost parameters should need a rehash")
+	}
+
+	if !NeedsRehash("$2a$10$abcdefghijklmnopqrstuv") {
+		t.Error("a legacy bcrypt hash should always need a rehash")
+	}
+}
+
+func TestCompareHashesLegacyBcrypt(t *testing.T) {
+	// bcrypt.MinCost keeps this test fast; CompareHashes must still fall
+	// back to bcrypt for any hash that isn't an argon2id PHC string.
+	raw, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword failed: %v", err)
+	}
+	bcryptHash := string(raw)
+
+	if err := CompareHashes(bcryptHash, "hunter2"); err != nil {
+		t.Errorf("CompareHashes rejected the correct password against a bcrypt hash: %v", err)
+	}
+	if err := CompareHashes(bcryptHash, "wrong"); err == nil {
+		t.Error("CompareHashes should reject the wrong password against a bcrypt hash")
+	}
+}